@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/build"
+	"os"
+	"strings"
+
+	"golang.org/x/tools/go/buildutil"
+)
+
+// overlayArchive is the on-disk shape of a `-overlay` file: the same
+// {"Replace": {real: replacement}} mapping understood by `go build -overlay`.
+type overlayArchive struct {
+	Replace map[string]string
+}
+
+// newBuildContext builds the *build.Context that all file discovery and
+// parsing is routed through, so build-tag evaluation and overlay
+// substitution are applied consistently regardless of which subcommand is
+// driving discovery. It also returns the parsed overlay map (real path ->
+// replacement content), nil if no overlay was given, so callers that need to
+// type-check with go/packages (which takes its own Overlay, independent of
+// build.Context) can apply the same substitution.
+func newBuildContext(tags []string, overlayPath string) (*build.Context, map[string][]byte, error) {
+	ctx := build.Default
+	ctx.BuildTags = tags
+
+	if overlayPath == "" {
+		return &ctx, nil, nil
+	}
+
+	data, err := os.ReadFile(overlayPath)
+	if err != nil {
+		return nil, nil, fmt.Errorf("reading overlay %s: %w", overlayPath, err)
+	}
+
+	var archive overlayArchive
+	if err := json.Unmarshal(data, &archive); err != nil {
+		return nil, nil, fmt.Errorf("parsing overlay %s: %w", overlayPath, err)
+	}
+
+	overlay := make(map[string][]byte, len(archive.Replace))
+	for real, replacement := range archive.Replace {
+		content, err := os.ReadFile(replacement)
+		if err != nil {
+			return nil, nil, fmt.Errorf("reading overlay replacement %s: %w", replacement, err)
+		}
+		overlay[real] = content
+	}
+
+	return buildutil.OverlayContext(&ctx, overlay), overlay, nil
+}
+
+// parseBuildTags splits a comma-separated `-tags` flag value the same way
+// `go build -tags` does.
+func parseBuildTags(tags string) []string {
+	if tags == "" {
+		return nil
+	}
+	return strings.Split(tags, ",")
+}
+
+// fileExists reports whether path exists under ctx, honoring any overlay.
+func fileExists(ctx *build.Context, path string) bool {
+	rc, err := buildutil.OpenFile(ctx, path)
+	if err != nil {
+		return false
+	}
+	rc.Close()
+	return true
+}
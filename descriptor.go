@@ -0,0 +1,86 @@
+package main
+
+import (
+	"go/ast"
+	"go/types"
+)
+
+// MarkerOptions holds the flags parsed out of a `//builder:generate` marker
+// comment, e.g. `//builder:generate name=FooBuilder pointer required`.
+type MarkerOptions struct {
+	// Name overrides the generated builder type name (default: <Struct>Builder).
+	Name string
+	// Pointer makes the builder operate on a *T target instead of a T target.
+	Pointer bool
+	// Required marks that Build should validate required fields before
+	// returning, rather than always succeeding.
+	Required bool
+	// Style selects the generated constructor shape: "fluent" (default) for
+	// the With<Field> setter-chain builder, or "options" for a functional-
+	// options New<T>(required..., opts ...<T>Option) (<T>, error)
+	// constructor. Overrides the Generator's default style when set.
+	Style string
+}
+
+// FieldDescriptor describes a single exported struct field discovered during
+// the AST walk, before any type resolution has happened.
+type FieldDescriptor struct {
+	Name     string
+	TypeExpr ast.Expr
+
+	// ResolvedType is the field's go/types.Type, filled in by
+	// resolveFieldTypes once the containing package has been type-checked.
+	// It is nil when type information wasn't available, in which case the
+	// Generator falls back to rendering TypeExpr syntactically.
+	ResolvedType types.Type
+
+	// Required comes from a `builder:"required"` struct tag. In options
+	// style, required fields become positional New<T> parameters instead of
+	// With<Field> options.
+	Required bool
+	// Validate comes from a `builder:"validate=<func>"` struct tag: the name
+	// of a `func(FieldType) error` invoked on this field in options style,
+	// with any error aggregated into New<T>'s returned error.
+	Validate string
+}
+
+// TypeParamDescriptor describes one type parameter of a generic struct, e.g.
+// the `K comparable` in `type Foo[T any, K comparable] struct{...}`.
+type TypeParamDescriptor struct {
+	Name           string
+	ConstraintExpr ast.Expr
+
+	// ResolvedConstraint is the type parameter's constraint as a
+	// go/types.Type, filled in alongside the struct's fields once the
+	// containing package has been type-checked. Nil falls back to
+	// ConstraintExpr rendered syntactically.
+	ResolvedConstraint types.Type
+}
+
+// StructDescriptor is the result of discovery: everything the Generator needs
+// to know about one struct annotated with `//builder:generate`, independent
+// of whether it was found via a single file, a package directory, or a
+// recursive module walk.
+type StructDescriptor struct {
+	FilePath    string
+	PackageName string
+	// PackagePath is the struct's import path, set once type resolution has
+	// run. It lets the Generator recognize (and skip qualifying) references
+	// back to the struct's own package.
+	PackagePath string
+	StructName  string
+	Fields      []FieldDescriptor
+	Options     MarkerOptions
+	// TypeParams holds the struct's type parameter list, empty for
+	// non-generic structs.
+	TypeParams []TypeParamDescriptor
+}
+
+// BuilderName returns the name to use for the generated builder type,
+// honoring a `name=` marker override if one was set.
+func (d *StructDescriptor) BuilderName() string {
+	if d.Options.Name != "" {
+		return d.Options.Name
+	}
+	return d.StructName + "Builder"
+}
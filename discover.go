@@ -0,0 +1,320 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/build"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"golang.org/x/tools/go/buildutil"
+)
+
+// markerPrefix is the doc-comment directive that opts a struct into builder
+// generation, mirroring the `//go:generate` convention.
+const markerPrefix = "builder:generate"
+
+// parseMarker looks for a `//builder:generate ...` line in a doc comment
+// group and, if found, parses its space-separated flags into MarkerOptions.
+// It reports ok=false if no marker line is present.
+func parseMarker(doc *ast.CommentGroup) (MarkerOptions, bool) {
+	if doc == nil {
+		return MarkerOptions{}, false
+	}
+	for _, c := range doc.List {
+		text := strings.TrimSpace(strings.TrimPrefix(c.Text, "//"))
+		if !strings.HasPrefix(text, markerPrefix) {
+			continue
+		}
+		rest := strings.TrimSpace(strings.TrimPrefix(text, markerPrefix))
+		opts := MarkerOptions{}
+		for _, field := range strings.Fields(rest) {
+			switch {
+			case strings.HasPrefix(field, "name="):
+				opts.Name = strings.TrimPrefix(field, "name=")
+			case field == "pointer":
+				opts.Pointer = true
+			case field == "required":
+				opts.Required = true
+			case strings.HasPrefix(field, "style="):
+				opts.Style = strings.TrimPrefix(field, "style=")
+			}
+		}
+		return opts, true
+	}
+	return MarkerOptions{}, false
+}
+
+// structDoc returns the doc comment that applies to a TypeSpec. The parser
+// attaches the doc to the surrounding GenDecl when the struct is declared on
+// its own (`type Foo struct{}`), and only attaches it directly to the
+// TypeSpec inside a parenthesized `type ( ... )` block, so both places have
+// to be checked.
+func structDoc(decl *ast.GenDecl, spec *ast.TypeSpec) *ast.CommentGroup {
+	if spec.Doc != nil {
+		return spec.Doc
+	}
+	if len(decl.Specs) == 1 {
+		return decl.Doc
+	}
+	return nil
+}
+
+// parseFieldTag reads the `builder:"..."` struct tag, if any, off field and
+// applies its comma-separated options (`required`, `validate=<func>`) to fd.
+func parseFieldTag(field *ast.Field, fd *FieldDescriptor) {
+	if field.Tag == nil {
+		return
+	}
+	raw, err := strconv.Unquote(field.Tag.Value)
+	if err != nil {
+		return
+	}
+	for _, opt := range strings.Split(reflect.StructTag(raw).Get("builder"), ",") {
+		switch {
+		case opt == "required":
+			fd.Required = true
+		case strings.HasPrefix(opt, "validate="):
+			fd.Validate = strings.TrimPrefix(opt, "validate=")
+		}
+	}
+}
+
+// extractFields collects the exported fields of a struct type into
+// FieldDescriptors. Embedded and unexported fields are skipped, matching the
+// builder's existing "public fields only" behavior.
+func extractFields(st *ast.StructType) []FieldDescriptor {
+	var fields []FieldDescriptor
+	for _, field := range st.Fields.List {
+		for _, name := range field.Names {
+			if name.IsExported() {
+				fd := FieldDescriptor{Name: name.Name, TypeExpr: field.Type}
+				parseFieldTag(field, &fd)
+				fields = append(fields, fd)
+			}
+		}
+	}
+	return fields
+}
+
+// extractTypeParams collects a generic struct's type parameter list. fl is
+// nil for non-generic structs.
+func extractTypeParams(fl *ast.FieldList) []TypeParamDescriptor {
+	if fl == nil {
+		return nil
+	}
+	var params []TypeParamDescriptor
+	for _, field := range fl.List {
+		for _, name := range field.Names {
+			params = append(params, TypeParamDescriptor{Name: name.Name, ConstraintExpr: field.Type})
+		}
+	}
+	return params
+}
+
+// discoverFileAST walks an already-parsed file and returns a descriptor for
+// every `//builder:generate`-annotated struct it finds. filePath and
+// packageName are recorded on each descriptor for later output placement.
+func discoverFileAST(node *ast.File, filePath, packageName string) []*StructDescriptor {
+	var found []*StructDescriptor
+	for _, decl := range node.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			typeSpec, ok := spec.(*ast.TypeSpec)
+			if !ok {
+				continue
+			}
+			structType, ok := typeSpec.Type.(*ast.StructType)
+			if !ok {
+				continue
+			}
+			opts, ok := parseMarker(structDoc(genDecl, typeSpec))
+			if !ok {
+				continue
+			}
+			found = append(found, &StructDescriptor{
+				FilePath:    filePath,
+				PackageName: packageName,
+				StructName:  typeSpec.Name.Name,
+				Fields:      extractFields(structType),
+				Options:     opts,
+				TypeParams:  extractTypeParams(typeSpec.TypeParams),
+			})
+		}
+	}
+	return found
+}
+
+// matchesContext reports whether filePath's build constraints (GOOS/GOARCH
+// suffix, `//go:build` lines, and ctx's configured tags) are satisfied, so
+// callers don't parse and generate from files that the active build context
+// wouldn't compile.
+func matchesContext(ctx *build.Context, filePath string) (bool, error) {
+	return ctx.MatchFile(filepath.Dir(filePath), filepath.Base(filePath))
+}
+
+// discoverFile parses a single Go source file through ctx and returns
+// descriptors for every annotated struct in it. It errors if filePath itself
+// doesn't match ctx's build constraints.
+func discoverFile(ctx *build.Context, filePath string) ([]*StructDescriptor, error) {
+	match, err := matchesContext(ctx, filePath)
+	if err != nil {
+		return nil, err
+	}
+	if !match {
+		return nil, fmt.Errorf("%s does not match the configured build constraints", filePath)
+	}
+
+	fset := token.NewFileSet()
+	node, err := buildutil.ParseFile(fset, ctx, nil, filepath.Dir(filePath), filepath.Base(filePath), parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	return discoverFileAST(node, filePath, node.Name.Name), nil
+}
+
+// discoverFileForStruct parses a single file and builds a descriptor for the
+// named struct regardless of whether it carries a `//builder:generate`
+// marker, preserving the tool's original explicit invocation style.
+func discoverFileForStruct(ctx *build.Context, filePath, structName string) ([]*StructDescriptor, error) {
+	fset := token.NewFileSet()
+	node, err := buildutil.ParseFile(fset, ctx, nil, filepath.Dir(filePath), filepath.Base(filePath), parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, decl := range node.Decls {
+		genDecl, ok := decl.(*ast.GenDecl)
+		if !ok || genDecl.Tok != token.TYPE {
+			continue
+		}
+		for _, spec := range genDecl.Specs {
+			typeSpec, ok := spec.(*ast.TypeSpec)
+			if !ok || typeSpec.Name.Name != structName {
+				continue
+			}
+			structType, ok := typeSpec.Type.(*ast.StructType)
+			if !ok {
+				continue
+			}
+			opts, _ := parseMarker(structDoc(genDecl, typeSpec))
+			return []*StructDescriptor{{
+				FilePath:    filePath,
+				PackageName: node.Name.Name,
+				StructName:  typeSpec.Name.Name,
+				Fields:      extractFields(structType),
+				Options:     opts,
+				TypeParams:  extractTypeParams(typeSpec.TypeParams),
+			}}, nil
+		}
+	}
+	return nil, fmt.Errorf("struct %s not found in %s", structName, filePath)
+}
+
+// discoverPackageDir parses every non-test Go file directly inside dir that
+// matches ctx's build constraints (no recursion into subdirectories) and
+// returns descriptors for every annotated struct found across them.
+func discoverPackageDir(ctx *build.Context, dir string) ([]*StructDescriptor, error) {
+	entries, err := buildutil.ReadDir(ctx, dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var found []*StructDescriptor
+	for _, entry := range entries {
+		if entry.IsDir() || !strings.HasSuffix(entry.Name(), ".go") || strings.HasSuffix(entry.Name(), "_test.go") {
+			continue
+		}
+
+		filePath := buildutil.JoinPath(ctx, dir, entry.Name())
+		match, err := matchesContext(ctx, filePath)
+		if err != nil {
+			return nil, err
+		}
+		if !match {
+			continue
+		}
+
+		descs, err := discoverFile(ctx, filePath)
+		if err != nil {
+			return nil, err
+		}
+		found = append(found, descs...)
+	}
+	return found, nil
+}
+
+// discoverModule walks root recursively, parsing every package it finds and
+// collecting descriptors for every annotated struct. It skips vendor
+// directories, symlinks (to avoid cycles), and any directory other than root
+// itself that contains its own go.mod, treating that as a nested module
+// boundary.
+func discoverModule(ctx *build.Context, root string) ([]*StructDescriptor, error) {
+	var found []*StructDescriptor
+
+	var walk func(dir string) error
+	walk = func(dir string) error {
+		if info, err := os.Lstat(dir); err == nil && info.Mode()&os.ModeSymlink != 0 {
+			return nil
+		}
+
+		base := filepath.Base(dir)
+		if dir != root && (base == "vendor" || strings.HasPrefix(base, ".")) {
+			return nil
+		}
+		if dir != root && fileExists(ctx, buildutil.JoinPath(ctx, dir, "go.mod")) {
+			return nil
+		}
+
+		descs, err := discoverPackageDir(ctx, dir)
+		if err != nil {
+			return err
+		}
+		found = append(found, descs...)
+
+		entries, err := buildutil.ReadDir(ctx, dir)
+		if err != nil {
+			return err
+		}
+		for _, entry := range entries {
+			if entry.IsDir() {
+				if err := walk(buildutil.JoinPath(ctx, dir, entry.Name())); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	if err := walk(root); err != nil {
+		return nil, err
+	}
+	return found, nil
+}
+
+// findModuleRoot walks upward from dir until it finds a directory containing
+// go.mod, returning that directory. If none is found, dir itself is returned.
+func findModuleRoot(ctx *build.Context, dir string) string {
+	abs, err := filepath.Abs(dir)
+	if err != nil {
+		return dir
+	}
+	for {
+		if fileExists(ctx, buildutil.JoinPath(ctx, abs, "go.mod")) {
+			return abs
+		}
+		parent := filepath.Dir(abs)
+		if parent == abs {
+			return dir
+		}
+		abs = parent
+	}
+}
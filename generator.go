@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"go/ast"
+	"go/types"
+	"strings"
+)
+
+// Generator renders builder source code for discovered structs. It is the
+// single code generation pipeline shared by the file, package, and module
+// discovery paths.
+type Generator struct {
+	// DefaultStyle is used for structs that don't override it via a
+	// `style=` marker option. Empty means styleFluent.
+	DefaultStyle string
+}
+
+// NewGenerator creates a Generator that renders in the given default style
+// ("fluent" or "options"; "" means fluent).
+func NewGenerator(defaultStyle string) *Generator {
+	return &Generator{DefaultStyle: defaultStyle}
+}
+
+// fieldTypeString renders the syntactic form of a field's type expression.
+// It's the fallback used when a field has no resolved go/types.Type, i.e.
+// when the containing package couldn't be type-checked.
+func fieldTypeString(expr ast.Expr) string {
+	switch t := expr.(type) {
+	case *ast.Ident:
+		return t.Name
+	case *ast.ArrayType:
+		return "[]" + fieldTypeString(t.Elt)
+	case *ast.StarExpr:
+		return "*" + fieldTypeString(t.X)
+	case *ast.SelectorExpr:
+		return fieldTypeString(t.X) + "." + t.Sel.Name
+	default:
+		return fmt.Sprintf("%T", t)
+	}
+}
+
+// unexportedForeignType reports whether t ultimately names an unexported
+// type belonging to a package other than selfPath, which would make a
+// `With<Field>` setter for it uncompilable outside that package.
+func unexportedForeignType(t types.Type, selfPath string) bool {
+	for {
+		switch u := t.(type) {
+		case *types.Pointer:
+			t = u.Elem()
+		case *types.Slice:
+			t = u.Elem()
+		case *types.Array:
+			t = u.Elem()
+		case *types.Chan:
+			t = u.Elem()
+		case *types.Named:
+			obj := u.Obj()
+			return !obj.Exported() && obj.Pkg() != nil && obj.Pkg().Path() != selfPath
+		default:
+			return false
+		}
+	}
+}
+
+// renderField renders a field's type for the generated source, using its
+// resolved go/types.Type and qualifier when available, falling back to the
+// syntactic AST rendering otherwise.
+func renderField(field FieldDescriptor, qualifier types.Qualifier) string {
+	if field.ResolvedType != nil {
+		return types.TypeString(field.ResolvedType, qualifier)
+	}
+	return fieldTypeString(field.TypeExpr)
+}
+
+// generateFluent renders the default With<Field> setter-chain builder. When
+// desc.Options.Pointer is set, the builder targets a *T instead of a T, and
+// when desc.Options.Required is set, Build validates every field tagged
+// `builder:"required"` and returns (T, error) instead of always succeeding.
+func (g *Generator) generateFluent(desc *StructDescriptor, body *strings.Builder, qualifier types.Qualifier, imports *importSet) {
+	builderName := desc.BuilderName()
+	typeParamDecl := desc.TypeParamDecl(qualifier)
+	typeArgs := desc.TypeArgs()
+
+	targetType := desc.TargetType()
+	fieldType := targetType
+	targetInit := fmt.Sprintf("%s{}", targetType)
+	if desc.Options.Pointer {
+		fieldType = "*" + targetType
+		targetInit = "&" + targetInit
+	}
+
+	body.WriteString(fmt.Sprintf("type %s%s struct {\n", builderName, typeParamDecl))
+	body.WriteString(fmt.Sprintf("    target %s\n", fieldType))
+	body.WriteString("}\n\n")
+
+	body.WriteString(fmt.Sprintf("func New%s%s() *%s%s {\n", builderName, typeParamDecl, builderName, typeArgs))
+	body.WriteString(fmt.Sprintf("    return &%s%s{target: %s}\n", builderName, typeArgs, targetInit))
+	body.WriteString("}\n\n")
+
+	for _, field := range desc.Fields {
+		if field.ResolvedType != nil && unexportedForeignType(field.ResolvedType, desc.PackagePath) {
+			body.WriteString(fmt.Sprintf("// %s: unexported type; skipped\n\n", field.Name))
+			continue
+		}
+
+		typeStr := renderField(field, qualifier)
+		body.WriteString(fmt.Sprintf("func (b *%s%s) With%s(value %s) *%s%s {\n", builderName, typeArgs, field.Name, typeStr, builderName, typeArgs))
+		body.WriteString(fmt.Sprintf("    b.target.%s = value\n", field.Name))
+		body.WriteString("    return b\n")
+		body.WriteString("}\n\n")
+	}
+
+	var required []FieldDescriptor
+	if desc.Options.Required {
+		for _, field := range desc.Fields {
+			if field.Required {
+				required = append(required, field)
+			}
+		}
+	}
+
+	if len(required) == 0 {
+		body.WriteString(fmt.Sprintf("func (b *%s%s) Build() %s {\n", builderName, typeArgs, fieldType))
+		body.WriteString("    return b.target\n")
+		body.WriteString("}\n")
+		return
+	}
+
+	imports.Require("errors")
+	imports.Require("fmt")
+	imports.Require("reflect")
+
+	zeroValue := targetInit
+	if desc.Options.Pointer {
+		zeroValue = "nil"
+	}
+
+	body.WriteString(fmt.Sprintf("func (b *%s%s) Build() (%s, error) {\n", builderName, typeArgs, fieldType))
+	body.WriteString("    var errs []error\n")
+	for _, field := range required {
+		// A field whose static type is an interface (a common choice for
+		// required dependencies like a logger) is its type's zero Value
+		// when left nil, and reflect.Value.IsZero panics on that. Guard
+		// with IsValid first instead of special-casing nilable kinds.
+		body.WriteString(fmt.Sprintf("    if v := reflect.ValueOf(b.target.%s); !v.IsValid() || v.IsZero() {\n", field.Name))
+		body.WriteString(fmt.Sprintf("        errs = append(errs, fmt.Errorf(\"%s is required\"))\n", field.Name))
+		body.WriteString("    }\n")
+	}
+	body.WriteString("    if err := errors.Join(errs...); err != nil {\n")
+	body.WriteString(fmt.Sprintf("        return %s, err\n", zeroValue))
+	body.WriteString("    }\n")
+	body.WriteString("    return b.target, nil\n")
+	body.WriteString("}\n")
+}
+
+// Generate renders the builder source for a single discovered struct, in
+// fluent or functional-options style depending on the struct's marker and
+// the Generator's default.
+func (g *Generator) Generate(desc *StructDescriptor) (string, error) {
+	if len(desc.Fields) == 0 {
+		return "", fmt.Errorf("no public fields found for struct %s", desc.StructName)
+	}
+
+	imports := newImportSet(desc.PackagePath)
+	qualifier := imports.Qualifier()
+
+	var body strings.Builder
+	switch g.effectiveStyle(desc) {
+	case styleOptions:
+		for _, field := range desc.Fields {
+			if field.Validate != "" {
+				imports.Require("errors")
+				break
+			}
+		}
+		if err := g.generateOptions(desc, &body, qualifier); err != nil {
+			return "", fmt.Errorf("%s: %w", desc.StructName, err)
+		}
+	default:
+		g.generateFluent(desc, &body, qualifier, imports)
+	}
+
+	var builderCode strings.Builder
+	builderCode.WriteString(fmt.Sprintf("package %s\n\n", desc.PackageName))
+	if block := imports.ImportBlock(); block != "" {
+		builderCode.WriteString(block)
+		builderCode.WriteString("\n")
+	}
+	builderCode.WriteString(body.String())
+
+	return builderCode.String(), nil
+}
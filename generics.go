@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"go/types"
+	"strings"
+)
+
+// constraintString renders a type parameter's constraint, preferring its
+// resolved go/types.Type (so constraints from other packages, like
+// constraints.Ordered, are correctly qualified and imported) and falling
+// back to the syntactic AST rendering otherwise.
+func constraintString(tp TypeParamDescriptor, qualifier types.Qualifier) string {
+	if tp.ResolvedConstraint != nil {
+		return types.TypeString(tp.ResolvedConstraint, qualifier)
+	}
+	return fieldTypeString(tp.ConstraintExpr)
+}
+
+// TypeParamDecl renders a struct's type parameter list with constraints,
+// e.g. "[T any, K comparable]", or "" for a non-generic struct. This is used
+// wherever a new generic declaration is introduced (a type decl or a
+// top-level func), as opposed to TypeArgs which is used to reference an
+// already-declared parameter list.
+func (d *StructDescriptor) TypeParamDecl(qualifier types.Qualifier) string {
+	if len(d.TypeParams) == 0 {
+		return ""
+	}
+	parts := make([]string, len(d.TypeParams))
+	for i, tp := range d.TypeParams {
+		parts[i] = fmt.Sprintf("%s %s", tp.Name, constraintString(tp, qualifier))
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
+
+// TypeArgs renders a struct's type parameter names only, e.g. "[T, K]", or
+// "" for a non-generic struct. Used to instantiate the struct/builder type
+// and on method receivers, where constraints aren't repeated.
+func (d *StructDescriptor) TypeArgs() string {
+	if len(d.TypeParams) == 0 {
+		return ""
+	}
+	names := make([]string, len(d.TypeParams))
+	for i, tp := range d.TypeParams {
+		names[i] = tp.Name
+	}
+	return "[" + strings.Join(names, ", ") + "]"
+}
+
+// TargetType renders the struct's own type, instantiated with its type
+// arguments if generic, e.g. "Foo[T, K]" or plain "Foo".
+func (d *StructDescriptor) TargetType() string {
+	return d.StructName + d.TypeArgs()
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"go/build"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeModule materializes files (path -> content, relative to root) under a
+// fresh temp directory and returns its root.
+func writeModule(t *testing.T, files map[string]string) string {
+	t.Helper()
+	root := t.TempDir()
+	for rel, content := range files {
+		path := filepath.Join(root, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("MkdirAll(%s): %s", filepath.Dir(path), err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("WriteFile(%s): %s", path, err)
+		}
+	}
+	return root
+}
+
+// generateSinglePkg discovers and generates the builder for the lone
+// annotated struct in dir, failing the test on any error.
+func generateSinglePkg(t *testing.T, dir string) string {
+	t.Helper()
+	ctx := &build.Default
+	descs, err := discoverPackageDir(ctx, dir)
+	if err != nil {
+		t.Fatalf("discoverPackageDir: %s", err)
+	}
+	if len(descs) != 1 {
+		t.Fatalf("expected exactly 1 discovered struct, got %d", len(descs))
+	}
+	enrichWithTypes(descs, nil, nil)
+
+	code, err := NewGenerator("").Generate(descs[0])
+	if err != nil {
+		t.Fatalf("Generate: %s", err)
+	}
+	return code
+}
+
+func TestGenerate_GenericStruct_ParameterizedFieldTypes(t *testing.T) {
+	root := writeModule(t, map[string]string{
+		"go.mod": "module gentest\n\ngo 1.21\n",
+		"sample/pair.go": `package sample
+
+//builder:generate
+type Pair[T any, K comparable] struct {
+	Value T
+	Tags  map[K]T
+}
+`,
+	})
+
+	code := generateSinglePkg(t, filepath.Join(root, "sample"))
+
+	for _, want := range []string{
+		"type PairBuilder[T any, K comparable] struct {",
+		"target Pair[T, K]",
+		"func (b *PairBuilder[T, K]) WithValue(value T) *PairBuilder[T, K] {",
+		"func (b *PairBuilder[T, K]) WithTags(value map[K]T) *PairBuilder[T, K] {",
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("generated code missing %q, got:\n%s", want, code)
+		}
+	}
+}
+
+func TestGenerate_GenericStruct_ParameterizedBuildReturn(t *testing.T) {
+	root := writeModule(t, map[string]string{
+		"go.mod": "module gentest\n\ngo 1.21\n",
+		"sample/box.go": `package sample
+
+//builder:generate
+type Box[T any] struct {
+	Value T
+}
+`,
+	})
+
+	code := generateSinglePkg(t, filepath.Join(root, "sample"))
+
+	want := "func (b *BoxBuilder[T]) Build() Box[T] {"
+	if !strings.Contains(code, want) {
+		t.Errorf("generated code missing %q, got:\n%s", want, code)
+	}
+}
+
+func TestGenerate_GenericStruct_ConstraintFromAnotherPackage(t *testing.T) {
+	root := writeModule(t, map[string]string{
+		"go.mod": "module gentest\n\ngo 1.21\n",
+		"constraints/constraints.go": `package constraints
+
+type Ordered interface {
+	~int | ~string
+}
+`,
+		"sample/box.go": `package sample
+
+import "gentest/constraints"
+
+//builder:generate
+type Box[T any, K constraints.Ordered] struct {
+	Value T
+	Key   K
+}
+`,
+	})
+
+	code := generateSinglePkg(t, filepath.Join(root, "sample"))
+
+	for _, want := range []string{
+		`"gentest/constraints"`,
+		"type BoxBuilder[T any, K constraints.Ordered] struct {",
+		"func (b *BoxBuilder[T, K]) WithKey(value K) *BoxBuilder[T, K] {",
+		"func (b *BoxBuilder[T, K]) Build() Box[T, K] {",
+	} {
+		if !strings.Contains(code, want) {
+			t.Errorf("generated code missing %q, got:\n%s", want, code)
+		}
+	}
+}
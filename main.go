@@ -1,131 +1,208 @@
 package main
 
 import (
+	"flag"
 	"fmt"
-	"go/ast"
-	"go/parser"
-	"go/token"
 	"os"
 	"path/filepath"
 	"strings"
+
+	"golang.org/x/tools/go/buildutil"
 )
 
-func fieldTypeString(expr ast.Expr) string {
-	switch t := expr.(type) {
-	case *ast.Ident:
-		return t.Name
-	case *ast.ArrayType:
-		return "[]" + fieldTypeString(t.Elt)
-	case *ast.StarExpr:
-		return "*" + fieldTypeString(t.X)
-	case *ast.SelectorExpr:
-		return fieldTypeString(t.X) + "." + t.Sel.Name
-	default:
-		return fmt.Sprintf("%T", t)
+// determineOutputFileName derives the `<typename>_builder.go` filename for a
+// discovered struct, named after the struct itself (not the source file) so
+// that multiple annotated structs in one file don't clobber each other.
+func determineOutputFileName(structName string) string {
+	return strings.ToLower(structName) + "_builder.go"
+}
+
+// writeBuilder renders desc and writes it next to the file it was discovered
+// in, as `<typename>_builder.go`.
+func writeBuilder(gen *Generator, desc *StructDescriptor) error {
+	code, err := gen.Generate(desc)
+	if err != nil {
+		return fmt.Errorf("%s: %w", desc.StructName, err)
+	}
+
+	outputPath := filepath.Join(filepath.Dir(desc.FilePath), determineOutputFileName(desc.StructName))
+	if err := os.WriteFile(outputPath, []byte(code), 0644); err != nil {
+		return fmt.Errorf("writing %s: %w", outputPath, err)
 	}
+
+	fmt.Printf("Builder generated and saved to: %s\n", outputPath)
+	return nil
+}
+
+// addBuildFlags registers the -tags and -overlay flags shared by every
+// subcommand and returns accessors for building the resulting build.Context.
+func addBuildFlags(fs *flag.FlagSet) (tags, overlay *string) {
+	tags = fs.String("tags", "", buildutil.TagsFlagDoc)
+	overlay = fs.String("overlay", "", "JSON file mapping real paths to replacement file paths, same shape as `go build -overlay`")
+	return tags, overlay
+}
+
+// addStyleFlag registers the -style flag shared by every subcommand. A
+// struct's own `style=` marker option overrides this default.
+func addStyleFlag(fs *flag.FlagSet) *string {
+	return fs.String("style", styleFluent, "default builder style: fluent (With<Field> setter chain) or options (functional options)")
 }
 
-func generateBuilderForStruct(filePath, structName, packageName string) (string, error) {
-	fset := token.NewFileSet()
-	node, err := parser.ParseFile(fset, filePath, nil, parser.ParseComments)
+func runGenFile(args []string) error {
+	fs := flag.NewFlagSet("gen file", flag.ExitOnError)
+	structName := fs.String("struct", "", "generate a builder for this struct even if it has no //builder:generate marker")
+	tags, overlay := addBuildFlags(fs)
+	style := addStyleFlag(fs)
+	fs.Parse(args)
+
+	if fs.NArg() < 1 {
+		return fmt.Errorf("usage: go-builder-gen gen file [-struct=Name] [-tags=...] [-overlay=...] <file_path>")
+	}
+	filePath := fs.Arg(0)
+
+	ctx, overlayData, err := newBuildContext(parseBuildTags(*tags), *overlay)
 	if err != nil {
-		return "", err
-	}
-
-	var fields []string
-	var fieldTypes []string
-
-	ast.Inspect(node, func(n ast.Node) bool {
-		t, ok := n.(*ast.TypeSpec)
-		if ok && t.Name.Name == structName {
-			s, ok := t.Type.(*ast.StructType)
-			if ok {
-				for _, field := range s.Fields.List {
-					if field.Names != nil {
-						for _, name := range field.Names {
-							// Only consider public fields
-							if name.IsExported() {
-								fields = append(fields, name.Name)
-								fieldTypes = append(fieldTypes, fieldTypeString(field.Type))
-							}
-						}
-					}
-				}
-			}
+		return err
+	}
+
+	descs, err := discoverFile(ctx, filePath)
+	if err != nil {
+		return err
+	}
+
+	if *structName != "" && len(descs) == 0 {
+		// No marker present: fall back to generating for the explicitly
+		// named struct, preserving the tool's original single-struct usage.
+		fileDescs, err := discoverFileForStruct(ctx, filePath, *structName)
+		if err != nil {
+			return err
 		}
-		return true
-	})
+		descs = fileDescs
+	}
 
-	if len(fields) == 0 {
-		return "", fmt.Errorf("no public fields found for struct %s", structName)
+	if len(descs) == 0 {
+		return fmt.Errorf("no //builder:generate structs found in %s", filePath)
 	}
 
-	var builderCode strings.Builder
-	builderCode.WriteString(fmt.Sprintf("package %s\n\n", packageName))
+	enrichWithTypes(descs, parseBuildTags(*tags), overlayData)
 
-	builderName := structName + "Builder"
+	gen := NewGenerator(*style)
+	for _, desc := range descs {
+		if err := writeBuilder(gen, desc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
-	builderCode.WriteString(fmt.Sprintf("type %s struct {\n", builderName))
-	builderCode.WriteString(fmt.Sprintf("    target %s\n", structName))
-	builderCode.WriteString("}\n\n")
+func runGenPkg(args []string) error {
+	fs := flag.NewFlagSet("gen pkg", flag.ExitOnError)
+	tags, overlay := addBuildFlags(fs)
+	style := addStyleFlag(fs)
+	fs.Parse(args)
 
-	builderCode.WriteString(fmt.Sprintf("func New%s() *%s {\n", builderName, builderName))
-	builderCode.WriteString(fmt.Sprintf("    return &%s{}\n", builderName))
-	builderCode.WriteString("}\n\n")
+	dir := "."
+	if fs.NArg() > 0 {
+		dir = fs.Arg(0)
+	}
 
-	for i, field := range fields {
-		builderCode.WriteString(fmt.Sprintf("func (b *%s) With%s(value %s) *%s {\n", builderName, field, fieldTypes[i], builderName))
-		builderCode.WriteString(fmt.Sprintf("    b.target.%s = value\n", field))
-		builderCode.WriteString(fmt.Sprintf("    return b\n"))
-		builderCode.WriteString("}\n\n")
+	ctx, overlayData, err := newBuildContext(parseBuildTags(*tags), *overlay)
+	if err != nil {
+		return err
 	}
 
-	builderCode.WriteString(fmt.Sprintf("func (b *%s) Build() %s {\n", builderName, structName))
-	builderCode.WriteString(fmt.Sprintf("    return b.target\n"))
-	builderCode.WriteString("}\n")
+	descs, err := discoverPackageDir(ctx, dir)
+	if err != nil {
+		return err
+	}
+	if len(descs) == 0 {
+		return fmt.Errorf("no //builder:generate structs found in %s", dir)
+	}
 
-	return builderCode.String(), nil
-}
+	enrichWithTypes(descs, parseBuildTags(*tags), overlayData)
 
-func determineOutputFileName(inputFileName string) string {
-	base := strings.TrimSuffix(inputFileName, filepath.Ext(inputFileName))
-	builderFileName := base + "_builder.go"
-	return builderFileName
+	gen := NewGenerator(*style)
+	for _, desc := range descs {
+		if err := writeBuilder(gen, desc); err != nil {
+			return err
+		}
+	}
+	return nil
 }
 
-func main() {
-	if len(os.Args) < 4 {
-		fmt.Println("Usage: go-builder-gen <file_path> <struct_name> <output_path> <package_name>")
-		return
+func runGenMod(args []string) error {
+	fs := flag.NewFlagSet("gen mod", flag.ExitOnError)
+	tags, overlay := addBuildFlags(fs)
+	style := addStyleFlag(fs)
+	fs.Parse(args)
+
+	root := "."
+	if fs.NArg() > 0 {
+		root = fs.Arg(0)
 	}
 
-	filePath := os.Args[1]
-	structName := os.Args[2]
-	outputPath := os.Args[3]
-	packageName := os.Args[4]
+	ctx, overlayData, err := newBuildContext(parseBuildTags(*tags), *overlay)
+	if err != nil {
+		return err
+	}
+	root = findModuleRoot(ctx, root)
 
-	code, err := generateBuilderForStruct(filePath, structName, packageName)
+	descs, err := discoverModule(ctx, root)
 	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
-		return
+		return err
+	}
+	if len(descs) == 0 {
+		return fmt.Errorf("no //builder:generate structs found under %s", root)
 	}
 
-	// Check if outputPath is a directory
-	info, err := os.Stat(outputPath)
-	if err == nil && info.IsDir() {
-		outputFileName := determineOutputFileName(filepath.Base(filePath))
-		outputPath = filepath.Join(outputPath, outputFileName)
+	enrichWithTypes(descs, parseBuildTags(*tags), overlayData)
+
+	gen := NewGenerator(*style)
+	for _, desc := range descs {
+		if err := writeBuilder(gen, desc); err != nil {
+			return err
+		}
 	}
+	return nil
+}
 
-	if err := os.MkdirAll(filepath.Dir(outputPath), 0755); err != nil {
-		fmt.Fprintf(os.Stderr, "Error creating directories: %s\n", err)
-		return
+func usage() {
+	fmt.Println("Usage: go-builder-gen gen <file|pkg|mod> [flags] [path]")
+	fmt.Println()
+	fmt.Println("  gen file [-struct=Name] <file_path>   generate builders for a single file")
+	fmt.Println("  gen pkg [dir]                          generate builders for one package directory")
+	fmt.Println("  gen mod [dir]                          generate builders for an entire module, recursively")
+	fmt.Println()
+	fmt.Println("Structs are discovered via a `//builder:generate` doc comment, e.g.:")
+	fmt.Println("  //builder:generate name=PersonBuilder pointer")
+	fmt.Println("  type Person struct { ... }")
+}
+
+func main() {
+	if len(os.Args) < 2 || os.Args[1] != "gen" {
+		usage()
+		os.Exit(1)
+	}
+	if len(os.Args) < 3 {
+		usage()
+		os.Exit(1)
 	}
 
-	if err := os.WriteFile(outputPath, []byte(code), 0644); err != nil {
-		fmt.Fprintf(os.Stderr, "Error writing to file: %s\n", err)
-		return
+	var err error
+	switch os.Args[2] {
+	case "file":
+		err = runGenFile(os.Args[3:])
+	case "pkg":
+		err = runGenPkg(os.Args[3:])
+	case "mod":
+		err = runGenMod(os.Args[3:])
+	default:
+		usage()
+		os.Exit(1)
 	}
 
-	fmt.Printf("Builder generated and saved to: %s\n", outputPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"fmt"
+	"go/types"
+	"strings"
+	"unicode"
+)
+
+const (
+	styleFluent  = "fluent"
+	styleOptions = "options"
+)
+
+// effectiveStyle resolves the style a struct should be generated with: its
+// own marker override if set, otherwise the Generator's configured default.
+func (g *Generator) effectiveStyle(desc *StructDescriptor) string {
+	if desc.Options.Style != "" {
+		return desc.Options.Style
+	}
+	if g.DefaultStyle != "" {
+		return g.DefaultStyle
+	}
+	return styleFluent
+}
+
+// lowerFirst turns a field name into an idiomatic parameter/local name,
+// lowercasing a leading run of acronym letters as a unit rather than just
+// the first rune, e.g. "Name" -> "name", "ID" -> "id", "IDNumber" ->
+// "idNumber", "APIKey" -> "apiKey".
+func lowerFirst(s string) string {
+	if s == "" {
+		return s
+	}
+	r := []rune(s)
+
+	i := 0
+	for i < len(r) && unicode.IsUpper(r[i]) {
+		i++
+	}
+	switch {
+	case i == 0:
+		return s
+	case i == len(r):
+		return strings.ToLower(s)
+	case i == 1:
+		r[0] = unicode.ToLower(r[0])
+	default:
+		// The run's last letter starts the next word (e.g. the "N" in
+		// "IDNumber"), so only the letters before it are part of the
+		// acronym being lowercased.
+		for j := 0; j < i-1; j++ {
+			r[j] = unicode.ToLower(r[j])
+		}
+	}
+	return string(r)
+}
+
+// generateOptions renders the functional-options form of the builder:
+// New<T>(required..., opts ...<T>Option) (<T>, error), with one With<Field>
+// option func per non-required field and a validate check per field tagged
+// `builder:"validate=..."`. A required field whose resolved type is an
+// unexported type belonging to another package fails generation outright,
+// since it would otherwise become an uncompilable New<T> parameter; the
+// same check on an optional field just skips its With<Field> option, as
+// generateFluent does for its setters.
+func (g *Generator) generateOptions(desc *StructDescriptor, body *strings.Builder, qualifier types.Qualifier) error {
+	optionType := desc.StructName + "Option"
+	typeParamDecl := desc.TypeParamDecl(qualifier)
+	typeArgs := desc.TypeArgs()
+	targetType := desc.TargetType()
+	optionTypeArgs := optionType + typeArgs
+
+	body.WriteString(fmt.Sprintf("type %s%s func(*%s)\n\n", optionType, typeParamDecl, targetType))
+
+	var required []FieldDescriptor
+	var optional []FieldDescriptor
+	for _, field := range desc.Fields {
+		if field.ResolvedType != nil && unexportedForeignType(field.ResolvedType, desc.PackagePath) {
+			if field.Required {
+				return fmt.Errorf("required field %s has an inaccessible type", field.Name)
+			}
+			body.WriteString(fmt.Sprintf("// %s: unexported type; skipped\n\n", field.Name))
+			continue
+		}
+		if field.Required {
+			required = append(required, field)
+		} else {
+			optional = append(optional, field)
+		}
+	}
+
+	var params []string
+	for _, field := range required {
+		params = append(params, fmt.Sprintf("%s %s", lowerFirst(field.Name), renderField(field, qualifier)))
+	}
+	params = append(params, "opts ..."+optionTypeArgs)
+
+	body.WriteString(fmt.Sprintf("func New%s%s(%s) (%s, error) {\n", desc.StructName, typeParamDecl, strings.Join(params, ", "), targetType))
+	body.WriteString(fmt.Sprintf("    target := %s{\n", targetType))
+	for _, field := range required {
+		body.WriteString(fmt.Sprintf("        %s: %s,\n", field.Name, lowerFirst(field.Name)))
+	}
+	body.WriteString("    }\n")
+	body.WriteString("    for _, opt := range opts {\n")
+	body.WriteString("        opt(&target)\n")
+	body.WriteString("    }\n\n")
+
+	var validated []FieldDescriptor
+	for _, field := range desc.Fields {
+		if field.Validate != "" {
+			validated = append(validated, field)
+		}
+	}
+	if len(validated) > 0 {
+		body.WriteString("    var errs []error\n")
+		for _, field := range validated {
+			body.WriteString(fmt.Sprintf("    if err := %s(target.%s); err != nil {\n", field.Validate, field.Name))
+			body.WriteString("        errs = append(errs, err)\n")
+			body.WriteString("    }\n")
+		}
+		body.WriteString("    if err := errors.Join(errs...); err != nil {\n")
+		body.WriteString(fmt.Sprintf("        return %s{}, err\n", targetType))
+		body.WriteString("    }\n\n")
+	}
+
+	body.WriteString(fmt.Sprintf("    return target, nil\n"))
+	body.WriteString("}\n\n")
+
+	for _, field := range optional {
+		typeStr := renderField(field, qualifier)
+		body.WriteString(fmt.Sprintf("func With%s%s(value %s) %s {\n", field.Name, typeParamDecl, typeStr, optionTypeArgs))
+		body.WriteString(fmt.Sprintf("    return func(t *%s) {\n", targetType))
+		body.WriteString(fmt.Sprintf("        t.%s = value\n", field.Name))
+		body.WriteString("    }\n")
+		body.WriteString("}\n\n")
+	}
+	return nil
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"fmt"
+	"go/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"golang.org/x/tools/go/packages"
+)
+
+// loadPackageTypes loads the package rooted at dir with full type
+// information, so fields can be rendered from their resolved go/types.Type
+// rather than re-derived syntactically from the AST. overlay is applied the
+// same way discovery applies it via build.Context, so a field whose
+// declaring file is overlaid is resolved from the replacement content, not
+// what's on disk.
+func loadPackageTypes(dir string, tags []string, overlay map[string][]byte) (*packages.Package, error) {
+	cfg := &packages.Config{
+		Mode:    packages.NeedName | packages.NeedTypes | packages.NeedTypesInfo | packages.NeedSyntax | packages.NeedImports | packages.NeedDeps,
+		Dir:     dir,
+		Overlay: overlay,
+	}
+	if len(tags) > 0 {
+		cfg.BuildFlags = []string{"-tags", strings.Join(tags, ",")}
+	}
+	pkgs, err := packages.Load(cfg, ".")
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) == 0 {
+		return nil, fmt.Errorf("no packages found in %s", dir)
+	}
+	pkg := pkgs[0]
+	if len(pkg.Errors) > 0 {
+		return pkg, pkg.Errors[0]
+	}
+	return pkg, nil
+}
+
+// resolveFieldTypes enriches desc's fields with their types.Type, looked up
+// by name from the loaded package's struct definition, and records the
+// struct's own import path so the Generator can avoid self-qualifying it.
+func resolveFieldTypes(pkg *packages.Package, desc *StructDescriptor) error {
+	obj := pkg.Types.Scope().Lookup(desc.StructName)
+	if obj == nil {
+		return fmt.Errorf("struct %s not found in package %s", desc.StructName, pkg.PkgPath)
+	}
+	named, ok := obj.Type().(*types.Named)
+	if !ok {
+		return fmt.Errorf("%s is not a named type", desc.StructName)
+	}
+	structType, ok := named.Underlying().(*types.Struct)
+	if !ok {
+		return fmt.Errorf("%s is not a struct", desc.StructName)
+	}
+
+	desc.PackagePath = pkg.PkgPath
+
+	if tparams := named.TypeParams(); tparams != nil && tparams.Len() == len(desc.TypeParams) {
+		for i := 0; i < tparams.Len(); i++ {
+			desc.TypeParams[i].ResolvedConstraint = tparams.At(i).Constraint()
+		}
+	}
+
+	byName := make(map[string]types.Type, structType.NumFields())
+	for i := 0; i < structType.NumFields(); i++ {
+		f := structType.Field(i)
+		byName[f.Name()] = f.Type()
+	}
+
+	for i := range desc.Fields {
+		if t, ok := byName[desc.Fields[i].Name]; ok {
+			desc.Fields[i].ResolvedType = t
+		}
+	}
+	return nil
+}
+
+// enrichWithTypes type-checks the package each descriptor was discovered in
+// and resolves its fields' go/types.Type, one packages.Load per directory so
+// structs sharing a package only pay the type-checking cost once. Type
+// errors are reported as warnings rather than aborting generation: the
+// Generator falls back to syntactic rendering for any field left
+// unresolved.
+func enrichWithTypes(descs []*StructDescriptor, tags []string, overlay map[string][]byte) {
+	byDir := make(map[string][]*StructDescriptor)
+	var order []string
+	for _, desc := range descs {
+		dir := filepath.Dir(desc.FilePath)
+		if _, ok := byDir[dir]; !ok {
+			order = append(order, dir)
+		}
+		byDir[dir] = append(byDir[dir], desc)
+	}
+
+	for _, dir := range order {
+		pkg, err := loadPackageTypes(dir, tags, overlay)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "warning: type-checking %s: %s\n", dir, err)
+			continue
+		}
+		for _, desc := range byDir[dir] {
+			if err := resolveFieldTypes(pkg, desc); err != nil {
+				fmt.Fprintf(os.Stderr, "warning: resolving types for %s: %s\n", desc.StructName, err)
+			}
+		}
+	}
+}
+
+// importSet accumulates the packages referenced while rendering a single
+// generated file, choosing a non-colliding local alias for each one.
+type importSet struct {
+	selfPath string
+	aliases  map[string]string // import path -> alias
+	used     map[string]string // alias -> import path
+}
+
+func newImportSet(selfPath string) *importSet {
+	return &importSet{selfPath: selfPath, aliases: map[string]string{}, used: map[string]string{}}
+}
+
+// Require records that path must be imported, even though nothing asked the
+// Qualifier to print a name from it (e.g. a stdlib package the generated
+// code calls directly, like "errors").
+func (s *importSet) Require(path string) {
+	if _, ok := s.aliases[path]; ok {
+		return
+	}
+	alias := pkgNameFromPath(path)
+	s.aliases[path] = alias
+	s.used[alias] = path
+}
+
+// Qualifier returns a types.Qualifier suitable for types.TypeString that
+// records every foreign package it's asked to qualify and picks a
+// collision-free alias for it. The struct's own package is never qualified,
+// since the generated builder lives alongside it.
+func (s *importSet) Qualifier() types.Qualifier {
+	return func(pkg *types.Package) string {
+		if pkg.Path() == s.selfPath {
+			return ""
+		}
+		if alias, ok := s.aliases[pkg.Path()]; ok {
+			return alias
+		}
+
+		alias := pkg.Name()
+		for {
+			existing, taken := s.used[alias]
+			if !taken || existing == pkg.Path() {
+				break
+			}
+			alias = alias + "_"
+		}
+
+		s.aliases[pkg.Path()] = alias
+		s.used[alias] = pkg.Path()
+		return alias
+	}
+}
+
+// ImportBlock renders the accumulated imports as a Go import block, or the
+// empty string if nothing was imported.
+func (s *importSet) ImportBlock() string {
+	if len(s.aliases) == 0 {
+		return ""
+	}
+
+	paths := make([]string, 0, len(s.aliases))
+	for path := range s.aliases {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var b strings.Builder
+	b.WriteString("import (\n")
+	for _, path := range paths {
+		alias := s.aliases[path]
+		if alias == pkgNameFromPath(path) {
+			b.WriteString(fmt.Sprintf("\t%q\n", path))
+		} else {
+			b.WriteString(fmt.Sprintf("\t%s %q\n", alias, path))
+		}
+	}
+	b.WriteString(")\n")
+	return b.String()
+}
+
+func pkgNameFromPath(path string) string {
+	idx := strings.LastIndex(path, "/")
+	if idx < 0 {
+		return path
+	}
+	return path[idx+1:]
+}